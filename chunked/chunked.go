@@ -0,0 +1,290 @@
+// Package chunked splits payloads too large for a single BSON document
+// (MongoDB's 16MB limit) into independently compressed frames, storing
+// them as an ordered set of child documents referencing a manifest —
+// GridFS's approach, but with per-chunk compression metadata and
+// client-side codecs from the compression package.
+package chunked
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dassandeep/mongo-compression-demo/compression"
+)
+
+// DefaultFrameSize splits payloads into 4MB frames, comfortably under the
+// 16MB BSON document limit even after per-chunk metadata overhead.
+const DefaultFrameSize = 4 * 1024 * 1024
+
+// Manifest describes an ordered set of compressed chunks that reconstitute
+// one logical payload.
+type Manifest struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Algorithm  string             `bson:"algorithm"`
+	FrameSize  int                `bson:"frame_size"`
+	TotalSize  int64              `bson:"total_size"`
+	ChunkCount int                `bson:"chunk_count"`
+}
+
+// Chunk is one compressed frame of a manifest's payload.
+type Chunk struct {
+	ManifestID       primitive.ObjectID `bson:"manifest_id"`
+	Index            int                `bson:"index"`
+	Algorithm        string             `bson:"algorithm"`
+	UncompressedSize int                `bson:"uncompressed_size"`
+	CRC32            uint32             `bson:"crc32"`
+	Payload          primitive.Binary   `bson:"payload"`
+}
+
+// Stats summarizes one Put call, for callers that want to report on it.
+type Stats struct {
+	ManifestID   primitive.ObjectID
+	ChunkCount   int
+	TotalRaw     int64
+	TotalStored  int64
+	CompressTime time.Duration
+}
+
+// Compressor splits, compresses, and reassembles payloads larger than a
+// single BSON document can hold.
+type Compressor struct {
+	manifests  *mongo.Collection
+	chunks     *mongo.Collection
+	registry   *compression.Registry
+	codec      compression.Codec
+	frameSize  int
+	workers    int
+	bufferPool *compression.BufferPool
+}
+
+// New returns a Compressor that stores manifests and chunks in db's
+// "chunk_manifests"/"chunk_payloads" collections, compressing each frame
+// with codec using frameSize-byte frames (0 uses DefaultFrameSize) across
+// workers goroutines (0 uses runtime.GOMAXPROCS(0)). registry is consulted
+// on Get to pick the codec recorded in the manifest, so a Compressor can
+// read back data written with a different codec; nil uses
+// compression.DefaultRegistry().
+func New(db *mongo.Database, registry *compression.Registry, codec compression.Codec, frameSize, workers int) *Compressor {
+	if registry == nil {
+		registry = compression.DefaultRegistry()
+	}
+	if frameSize <= 0 {
+		frameSize = DefaultFrameSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	return &Compressor{
+		manifests:  db.Collection("chunk_manifests"),
+		chunks:     db.Collection("chunk_payloads"),
+		registry:   registry,
+		codec:      codec,
+		frameSize:  frameSize,
+		workers:    workers,
+		bufferPool: compression.NewBufferPool(),
+	}
+}
+
+// Put reads r to completion, splitting it into frameSize frames that are
+// compressed across a worker pool and stored as Chunks referencing a new
+// Manifest. It returns the manifest ID and stats describing the run.
+func (c *Compressor) Put(ctx context.Context, r io.Reader) (primitive.ObjectID, Stats, error) {
+	manifestID := primitive.NewObjectID()
+	start := time.Now()
+
+	type frame struct {
+		index int
+		buf   *compression.Buffer
+		n     int
+	}
+	type result struct {
+		index int
+		raw   int
+		chunk Chunk
+		buf   *compression.Buffer
+	}
+
+	frames := make(chan frame)
+	results := make(chan result)
+
+	// compressErrs collects per-frame compression errors behind a mutex
+	// rather than a fixed-capacity channel: a payload can have far more
+	// frames than workers, and a channel sized to the worker count would
+	// block a worker forever once more than that many frames fail.
+	var errMu sync.Mutex
+	var compressErrs []error
+
+	var workerWG sync.WaitGroup
+	for i := 0; i < c.workers; i++ {
+		workerWG.Add(1)
+		go func() {
+			defer workerWG.Done()
+			for f := range frames {
+				data := f.buf.B[:f.n]
+				checksum := crc32.ChecksumIEEE(data)
+
+				compressedBuf := c.bufferPool.Get(f.n)
+				payload, err := c.codec.CompressInto(compressedBuf.B, data)
+				c.bufferPool.Put(f.buf)
+				if err != nil {
+					errMu.Lock()
+					compressErrs = append(compressErrs, fmt.Errorf("chunked: compress chunk %d: %w", f.index, err))
+					errMu.Unlock()
+					c.bufferPool.Put(compressedBuf)
+					continue
+				}
+				compressedBuf.B = payload
+
+				results <- result{
+					index: f.index,
+					raw:   f.n,
+					chunk: Chunk{
+						ManifestID:       manifestID,
+						Index:            f.index,
+						Algorithm:        c.codec.Name(),
+						UncompressedSize: f.n,
+						CRC32:            checksum,
+						Payload:          primitive.Binary{Data: payload},
+					},
+					buf: compressedBuf,
+				}
+			}
+		}()
+	}
+	go func() {
+		workerWG.Wait()
+		close(results)
+	}()
+
+	var readErr error
+	go func() {
+		defer close(frames)
+		for index := 0; ; index++ {
+			buf := c.bufferPool.Get(c.frameSize)
+			buf.B = buf.B[:c.frameSize]
+
+			n, err := io.ReadFull(r, buf.B)
+			if n > 0 {
+				frames <- frame{index: index, buf: buf, n: n}
+			} else {
+				c.bufferPool.Put(buf)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				readErr = err
+				return
+			}
+		}
+	}()
+
+	var stats Stats
+	stats.ManifestID = manifestID
+
+	for res := range results {
+		_, err := c.chunks.InsertOne(ctx, res.chunk)
+		c.bufferPool.Put(res.buf)
+		if err != nil {
+			return manifestID, stats, fmt.Errorf("chunked: store chunk %d: %w", res.index, err)
+		}
+		stats.ChunkCount++
+		stats.TotalRaw += int64(res.raw)
+		stats.TotalStored += int64(len(res.chunk.Payload.Data))
+	}
+
+	errMu.Lock()
+	firstErr := error(nil)
+	if len(compressErrs) > 0 {
+		firstErr = compressErrs[0]
+	}
+	errMu.Unlock()
+	if firstErr != nil {
+		return manifestID, stats, firstErr
+	}
+	if readErr != nil {
+		return manifestID, stats, fmt.Errorf("chunked: read payload: %w", readErr)
+	}
+
+	stats.CompressTime = time.Since(start)
+
+	manifest := Manifest{
+		ID:         manifestID,
+		Algorithm:  c.codec.Name(),
+		FrameSize:  c.frameSize,
+		TotalSize:  stats.TotalRaw,
+		ChunkCount: stats.ChunkCount,
+	}
+	if _, err := c.manifests.InsertOne(ctx, manifest); err != nil {
+		return manifestID, stats, fmt.Errorf("chunked: store manifest: %w", err)
+	}
+
+	return manifestID, stats, nil
+}
+
+// Get reconstructs the payload stored under manifestID as a stream,
+// decompressing and CRC-checking one chunk at a time so memory usage stays
+// bounded by a single frame rather than the whole payload.
+func (c *Compressor) Get(ctx context.Context, manifestID primitive.ObjectID) (io.ReadCloser, error) {
+	var manifest Manifest
+	if err := c.manifests.FindOne(ctx, bson.M{"_id": manifestID}).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("chunked: load manifest: %w", err)
+	}
+
+	codec, err := c.registry.Get(manifest.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := c.chunks.Find(ctx,
+		bson.M{"manifest_id": manifestID},
+		options.Find().SetSort(bson.D{{Key: "index", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("chunked: list chunks: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var chunk Chunk
+			if err := cursor.Decode(&chunk); err != nil {
+				pw.CloseWithError(fmt.Errorf("chunked: decode chunk: %w", err))
+				return
+			}
+
+			data, err := codec.Decompress(chunk.Payload.Data)
+			if err != nil {
+				pw.CloseWithError(fmt.Errorf("chunked: decompress chunk %d: %w", chunk.Index, err))
+				return
+			}
+			if crc32.ChecksumIEEE(data) != chunk.CRC32 {
+				pw.CloseWithError(fmt.Errorf("chunked: chunk %d failed crc check", chunk.Index))
+				return
+			}
+			if _, err := pw.Write(data); err != nil {
+				return
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			pw.CloseWithError(fmt.Errorf("chunked: iterate chunks: %w", err))
+			return
+		}
+		pw.Close()
+	}()
+
+	return pr, nil
+}
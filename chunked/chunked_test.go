@@ -0,0 +1,59 @@
+package chunked
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// alwaysFailCodec fails every compress/decompress call, simulating a codec
+// error encountered mid-stream.
+type alwaysFailCodec struct{}
+
+func (alwaysFailCodec) Name() string                                 { return "always-fail" }
+func (alwaysFailCodec) Compress(src []byte) ([]byte, error)          { return nil, fmt.Errorf("boom") }
+func (alwaysFailCodec) CompressInto(dst, src []byte) ([]byte, error) { return nil, fmt.Errorf("boom") }
+func (alwaysFailCodec) Decompress(src []byte) ([]byte, error)        { return nil, fmt.Errorf("boom") }
+func (alwaysFailCodec) DecompressInto(dst, src []byte) ([]byte, error) {
+	return nil, fmt.Errorf("boom")
+}
+
+// TestPutReturnsWhenMoreFramesFailThanWorkers guards against a deadlock
+// where compression errors were collected on a channel sized to the worker
+// count: once more frames failed than there were workers, a worker would
+// block forever sending its error, and Put would never return.
+func TestPutReturnsWhenMoreFramesFailThanWorkers(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("mongo.Connect: %v", err)
+	}
+	db := client.Database("chunked_test")
+
+	const frameSize = 16
+	const workers = 2
+	const frameCount = 20 // well over workers, so every worker fails more than once
+
+	c := New(db, nil, alwaysFailCodec{}, frameSize, workers)
+	payload := bytes.Repeat([]byte("x"), frameSize*frameCount)
+
+	done := make(chan struct{})
+	var putErr error
+	go func() {
+		defer close(done)
+		_, _, putErr = c.Put(context.Background(), bytes.NewReader(payload))
+	}()
+
+	select {
+	case <-done:
+		if putErr == nil {
+			t.Fatal("expected Put to return an error from the always-failing codec")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Put deadlocked instead of returning an error")
+	}
+}
@@ -0,0 +1,93 @@
+// Package compression applies client-side compression to BSON payloads
+// before they are sent to MongoDB, independent of the driver's built-in
+// wire compressors (snappy/zlib/zstd). Compressing client-side lets callers
+// pick algorithms the driver doesn't support (s2, lz4) and reuse trained
+// dictionaries across small documents.
+package compression
+
+import "fmt"
+
+// Codec compresses and decompresses a byte payload using one algorithm.
+type Codec interface {
+	// Name returns the codec's registry name, e.g. "zstd" or "s2".
+	Name() string
+
+	// Compress returns the compressed form of src as a newly allocated
+	// slice. It is a convenience wrapper around CompressInto(nil, src).
+	Compress(src []byte) ([]byte, error)
+	// CompressInto compresses src, reusing dst's backing array when its
+	// capacity is large enough, and returns the result. dst's existing
+	// contents are not preserved — pass a scratch buffer (e.g. from a
+	// BufferPool), not data you still need.
+	CompressInto(dst, src []byte) ([]byte, error)
+
+	// Decompress returns the decompressed form of src as a newly
+	// allocated slice. It is a convenience wrapper around
+	// DecompressInto(nil, src).
+	Decompress(src []byte) ([]byte, error)
+	// DecompressInto is the buffer-reusing analog of Decompress, with the
+	// same dst contract as CompressInto.
+	DecompressInto(dst, src []byte) ([]byte, error)
+}
+
+// namedCodec overrides the Name() of an underlying Codec, so two different
+// configurations of the same implementation (e.g. zstd with and without a
+// trained dictionary) can be registered side by side under distinct names.
+type namedCodec struct {
+	Codec
+	name string
+}
+
+func (n namedCodec) Name() string { return n.name }
+
+// WithName returns codec wrapped so its registry name is name instead of
+// its own Name(). Useful for Registering a second, differently-configured
+// instance of a codec that would otherwise collide on its default name.
+func WithName(codec Codec, name string) Codec {
+	return namedCodec{Codec: codec, name: name}
+}
+
+// Registry looks codecs up by name so callers can select an algorithm at
+// runtime (CLI flag, config, benchmark sweep) without a type switch.
+type Registry struct {
+	codecs map[string]Codec
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{codecs: make(map[string]Codec)}
+}
+
+// Register adds codec to the registry, keyed by its Name().
+func (r *Registry) Register(codec Codec) {
+	r.codecs[codec.Name()] = codec
+}
+
+// Get returns the codec registered under name.
+func (r *Registry) Get(name string) (Codec, error) {
+	codec, ok := r.codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("compression: no codec registered for %q", name)
+	}
+	return codec, nil
+}
+
+// Names returns the registered codec names.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.codecs))
+	for name := range r.codecs {
+		names = append(names, name)
+	}
+	return names
+}
+
+// DefaultRegistry returns a Registry populated with all built-in codecs.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(NewZstdCodec(nil))
+	r.Register(NewS2Codec())
+	r.Register(NewGzipCodec())
+	r.Register(NewFlateCodec())
+	r.Register(NewLZ4Codec())
+	return r
+}
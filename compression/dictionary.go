@@ -0,0 +1,43 @@
+package compression
+
+import "os"
+
+// DefaultDictionarySize caps the dictionary TrainDictionary produces,
+// following zstd's guidance that dictionaries much larger than ~100KB
+// rarely help further.
+const DefaultDictionarySize = 64 * 1024
+
+// TrainDictionary builds a raw-content zstd dictionary from a corpus of
+// sample documents so that small/medium BSON payloads, which don't carry
+// enough internal repetition for zstd's dictionaryless mode to exploit,
+// compress against shared structure instead. Samples are concatenated up
+// to maxSize (0 uses DefaultDictionarySize); this is a raw-content
+// dictionary, not a trained COVER/FastCover one, but zstd accepts both.
+func TrainDictionary(samples [][]byte, maxSize int) []byte {
+	if maxSize <= 0 {
+		maxSize = DefaultDictionarySize
+	}
+
+	dict := make([]byte, 0, maxSize)
+	for _, sample := range samples {
+		if len(dict) >= maxSize {
+			break
+		}
+		remaining := maxSize - len(dict)
+		if len(sample) > remaining {
+			sample = sample[:remaining]
+		}
+		dict = append(dict, sample...)
+	}
+	return dict
+}
+
+// LoadDictionary reads a previously trained dictionary from path.
+func LoadDictionary(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// SaveDictionary writes dict to path for reuse across processes.
+func SaveDictionary(dict []byte, path string) error {
+	return os.WriteFile(path, dict, 0o644)
+}
@@ -0,0 +1,50 @@
+package compression
+
+import (
+	"bytes"
+
+	"github.com/klauspost/compress/flate"
+)
+
+// FlateCodec compresses payloads with klauspost's raw DEFLATE
+// implementation, skipping gzip's container overhead.
+type FlateCodec struct{}
+
+// NewFlateCodec returns a flate Codec.
+func NewFlateCodec() *FlateCodec { return &FlateCodec{} }
+
+func (c *FlateCodec) Name() string { return "flate" }
+
+func (c *FlateCodec) Compress(src []byte) ([]byte, error) {
+	return c.CompressInto(nil, src)
+}
+
+func (c *FlateCodec) CompressInto(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst[:0])
+	w, err := flate.NewWriter(buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *FlateCodec) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressInto(nil, src)
+}
+
+func (c *FlateCodec) DecompressInto(dst, src []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(src))
+	defer r.Close()
+
+	buf := bytes.NewBuffer(dst[:0])
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
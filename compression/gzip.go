@@ -0,0 +1,51 @@
+package compression
+
+import (
+	"bytes"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+// GzipCodec compresses payloads with klauspost's gzip, a drop-in
+// replacement for the standard library's compress/gzip with better
+// throughput.
+type GzipCodec struct{}
+
+// NewGzipCodec returns a gzip Codec.
+func NewGzipCodec() *GzipCodec { return &GzipCodec{} }
+
+func (c *GzipCodec) Name() string { return "gzip" }
+
+func (c *GzipCodec) Compress(src []byte) ([]byte, error) {
+	return c.CompressInto(nil, src)
+}
+
+func (c *GzipCodec) CompressInto(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst[:0])
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *GzipCodec) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressInto(nil, src)
+}
+
+func (c *GzipCodec) DecompressInto(dst, src []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	buf := bytes.NewBuffer(dst[:0])
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,45 @@
+package compression
+
+import (
+	"bytes"
+
+	"github.com/pierrec/lz4/v4"
+)
+
+// LZ4Codec compresses payloads with LZ4, trading compression ratio for
+// very high encode/decode throughput.
+type LZ4Codec struct{}
+
+// NewLZ4Codec returns an LZ4 Codec.
+func NewLZ4Codec() *LZ4Codec { return &LZ4Codec{} }
+
+func (c *LZ4Codec) Name() string { return "lz4" }
+
+func (c *LZ4Codec) Compress(src []byte) ([]byte, error) {
+	return c.CompressInto(nil, src)
+}
+
+func (c *LZ4Codec) CompressInto(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst[:0])
+	w := lz4.NewWriter(buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *LZ4Codec) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressInto(nil, src)
+}
+
+func (c *LZ4Codec) DecompressInto(dst, src []byte) ([]byte, error) {
+	r := lz4.NewReader(bytes.NewReader(src))
+	buf := bytes.NewBuffer(dst[:0])
+	if _, err := buf.ReadFrom(r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
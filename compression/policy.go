@@ -0,0 +1,107 @@
+package compression
+
+import (
+	"bytes"
+	"math"
+	"time"
+)
+
+// estimationWindow caps how much of a payload the estimator samples, so
+// estimation cost stays roughly constant regardless of document size.
+const estimationWindow = 16 * 1024
+
+// ContentType identifies a binary format by its magic bytes.
+type ContentType struct {
+	Name  string
+	Magic []byte
+}
+
+// Common already-compressed or inherently incompressible content types.
+var (
+	ContentTypeJPEG = ContentType{Name: "jpeg", Magic: []byte{0xFF, 0xD8, 0xFF}}
+	ContentTypePNG  = ContentType{Name: "png", Magic: []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}}
+	ContentTypeGzip = ContentType{Name: "gzip", Magic: []byte{0x1F, 0x8B}}
+	ContentTypeZstd = ContentType{Name: "zstd", Magic: []byte{0x28, 0xB5, 0x2F, 0xFD}}
+)
+
+// Policy decides whether a payload is worth compressing before the caller
+// pays for a full Codec.Compress pass.
+type Policy struct {
+	// UncompressableContentTypes are skipped outright once detected via
+	// magic bytes, e.g. already-compressed BinData or image payloads.
+	UncompressableContentTypes []ContentType
+
+	// UseCompressEstimation samples the payload with a fast entropy
+	// estimator before committing to full compression.
+	UseCompressEstimation bool
+
+	// CompressEstimationThreshold is the estimated compressed/original
+	// size ratio (0.0-1.0) at or above which compression is skipped as
+	// not worthwhile. Lower values only compress payloads estimated to
+	// shrink substantially.
+	CompressEstimationThreshold float64
+}
+
+// DefaultPolicy skips common already-compressed formats and estimates
+// before compressing, rejecting payloads estimated to shrink by less
+// than ~10%.
+func DefaultPolicy() Policy {
+	return Policy{
+		UncompressableContentTypes:  []ContentType{ContentTypeJPEG, ContentTypePNG, ContentTypeGzip, ContentTypeZstd},
+		UseCompressEstimation:       true,
+		CompressEstimationThreshold: 0.9,
+	}
+}
+
+// Evaluate decides whether src should be compressed. skip is true when a
+// known uncompressable content type was detected, or estimation found src
+// unlikely to compress past the configured threshold. estimatedRatio and
+// estimationTime are populated whenever the estimator ran.
+func (p Policy) Evaluate(src []byte) (skip bool, estimatedRatio float64, estimationTime time.Duration) {
+	for _, ct := range p.UncompressableContentTypes {
+		if bytes.HasPrefix(src, ct.Magic) {
+			return true, 0, 0
+		}
+	}
+
+	if !p.UseCompressEstimation {
+		return false, 0, 0
+	}
+
+	start := time.Now()
+	estimatedRatio = estimateCompressionRatio(src)
+	estimationTime = time.Since(start)
+
+	return estimatedRatio >= p.CompressEstimationThreshold, estimatedRatio, estimationTime
+}
+
+// estimateCompressionRatio samples up to estimationWindow bytes of src and
+// predicts the compressed/original size ratio from the Shannon entropy of
+// the byte distribution — the same symbol-frequency pass a Huff0 table
+// build does, without running a full entropy coder over the payload.
+func estimateCompressionRatio(src []byte) float64 {
+	sample := src
+	if len(sample) > estimationWindow {
+		sample = sample[:estimationWindow]
+	}
+	if len(sample) == 0 {
+		return 1
+	}
+
+	var freq [256]int
+	for _, b := range sample {
+		freq[b]++
+	}
+
+	entropy := 0.0
+	n := float64(len(sample))
+	for _, count := range freq {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+
+	return entropy / 8
+}
@@ -0,0 +1,86 @@
+package compression
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestPolicyEvaluateSkipsKnownContentTypes(t *testing.T) {
+	policy := DefaultPolicy()
+
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{"jpeg", append(append([]byte{}, ContentTypeJPEG.Magic...), []byte("rest of a jpeg")...)},
+		{"png", append(append([]byte{}, ContentTypePNG.Magic...), []byte("rest of a png")...)},
+		{"gzip", append(append([]byte{}, ContentTypeGzip.Magic...), []byte("rest of a gzip stream")...)},
+		{"zstd", append(append([]byte{}, ContentTypeZstd.Magic...), []byte("rest of a zstd frame")...)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			skip, estimatedRatio, estimationTime := policy.Evaluate(tc.src)
+			if !skip {
+				t.Fatalf("Evaluate(%s) = skip false, want true", tc.name)
+			}
+			if estimatedRatio != 0 || estimationTime != 0 {
+				t.Fatalf("Evaluate(%s) = ratio %v, time %v; want zero values since estimation shouldn't run for a detected content type", tc.name, estimatedRatio, estimationTime)
+			}
+		})
+	}
+}
+
+func TestPolicyEvaluateWithoutEstimationNeverSkips(t *testing.T) {
+	policy := Policy{UseCompressEstimation: false}
+
+	skip, estimatedRatio, estimationTime := policy.Evaluate(bytes.Repeat([]byte{0}, 1024))
+	if skip {
+		t.Fatal("Evaluate with UseCompressEstimation false should never skip")
+	}
+	if estimatedRatio != 0 || estimationTime != 0 {
+		t.Fatalf("Evaluate with estimation disabled returned ratio %v, time %v; want zero values", estimatedRatio, estimationTime)
+	}
+}
+
+func TestPolicyEvaluateUsesEstimationThreshold(t *testing.T) {
+	repetitive := []byte(strings.Repeat("a", 16*1024))
+	random := make([]byte, 16*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	policy := DefaultPolicy()
+
+	skip, ratio, _ := policy.Evaluate(repetitive)
+	if skip {
+		t.Fatalf("repetitive input estimated ratio %.3f, expected it to clear the %.2f threshold", ratio, policy.CompressEstimationThreshold)
+	}
+
+	skip, ratio, _ = policy.Evaluate(random)
+	if !skip {
+		t.Fatalf("random input estimated ratio %.3f, expected it to be skipped at the %.2f threshold", ratio, policy.CompressEstimationThreshold)
+	}
+}
+
+func TestEstimateCompressionRatioLowForRepetitiveHighForRandom(t *testing.T) {
+	repetitive := []byte(strings.Repeat("ab", 8*1024))
+	random := make([]byte, 16*1024)
+	rand.New(rand.NewSource(2)).Read(random)
+
+	repetitiveRatio := estimateCompressionRatio(repetitive)
+	randomRatio := estimateCompressionRatio(random)
+
+	if repetitiveRatio >= randomRatio {
+		t.Fatalf("expected repetitive input's estimated ratio (%.3f) to be lower than random input's (%.3f)", repetitiveRatio, randomRatio)
+	}
+	if randomRatio < 0.9 {
+		t.Fatalf("random input's estimated ratio %.3f is too low for high-entropy data", randomRatio)
+	}
+}
+
+func TestEstimateCompressionRatioEmptyInput(t *testing.T) {
+	if ratio := estimateCompressionRatio(nil); ratio != 1 {
+		t.Fatalf("estimateCompressionRatio(nil) = %v, want 1", ratio)
+	}
+}
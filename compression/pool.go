@@ -0,0 +1,92 @@
+package compression
+
+import "sync"
+
+// sizeClasses are the bucket capacities BufferPool rounds requests up to,
+// so a handful of sync.Pools cover a wide range of payload sizes instead
+// of every distinct size missing its own pool.
+var sizeClasses = []int{
+	4 * 1024,
+	16 * 1024,
+	64 * 1024,
+	256 * 1024,
+	1024 * 1024,
+	4 * 1024 * 1024,
+	16 * 1024 * 1024,
+}
+
+// Buffer is a reusable byte buffer obtained from a BufferPool. Callers
+// read/write B directly and call BufferPool.Put when done with it.
+type Buffer struct {
+	B     []byte
+	class int
+}
+
+// BufferPool hands out size-classed []byte buffers backed by sync.Pool, so
+// hot paths that run thousands of times — benchmark sweeps, chunked
+// compression workers — don't churn the allocator on every call.
+type BufferPool struct {
+	pools []sync.Pool
+}
+
+// NewBufferPool returns a ready-to-use BufferPool.
+func NewBufferPool() *BufferPool {
+	return &BufferPool{pools: make([]sync.Pool, len(sizeClasses))}
+}
+
+// Get returns a Buffer whose B has capacity >= minCap and length 0.
+// Requests larger than the biggest size class are allocated directly and
+// not pooled.
+func (p *BufferPool) Get(minCap int) *Buffer {
+	class := classFor(minCap)
+	if class < 0 {
+		return &Buffer{B: make([]byte, 0, minCap), class: -1}
+	}
+
+	if v := p.pools[class].Get(); v != nil {
+		buf := v.(*Buffer)
+		buf.B = buf.B[:0]
+		return buf
+	}
+	return &Buffer{B: make([]byte, 0, sizeClasses[class]), class: class}
+}
+
+// Put returns buf to its size class's pool. The class is recomputed from
+// cap(buf.B) rather than trusting buf.class, since callers like
+// bson.MarshalAppend can grow B well past the capacity it was handed out
+// with — without this, a buffer fetched from the 4KB class but grown to
+// several MB would be pooled back into the 4KB class, handing out
+// multi-MB buffers to callers asking for 4KB ones. A buffer goes to the
+// largest class whose size its capacity covers, so every pooled buffer
+// can serve any request its class accepts. Buffers smaller than the
+// smallest class or larger than the biggest one are dropped rather than
+// pooled.
+func (p *BufferPool) Put(buf *Buffer) {
+	if buf == nil {
+		return
+	}
+	c := cap(buf.B)
+	if c > sizeClasses[len(sizeClasses)-1] {
+		return
+	}
+	class := -1
+	for i, size := range sizeClasses {
+		if c >= size {
+			class = i
+		}
+	}
+	if class < 0 {
+		return
+	}
+	buf.class = class
+	p.pools[class].Put(buf)
+}
+
+func classFor(minCap int) int {
+	for i, size := range sizeClasses {
+		if minCap <= size {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,38 @@
+package compression
+
+import "testing"
+
+// BenchmarkCompressAllocUnpooled and BenchmarkCompressAllocPooled simulate
+// thousands of insert-style encode cycles to quantify the allocation
+// savings CompressInto's buffer reuse gives over always allocating a
+// fresh output slice, run with:
+//
+//	go test ./compression/ -bench=CompressAlloc -benchmem
+func BenchmarkCompressAllocUnpooled(b *testing.B) {
+	codec := NewZstdCodec(nil)
+	src := make([]byte, 64*1024)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := codec.Compress(src); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCompressAllocPooled(b *testing.B) {
+	codec := NewZstdCodec(nil)
+	src := make([]byte, 64*1024)
+	pool := NewBufferPool()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := pool.Get(len(src))
+		out, err := codec.CompressInto(buf.B, src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		buf.B = out
+		pool.Put(buf)
+	}
+}
@@ -0,0 +1,51 @@
+package compression
+
+import "testing"
+
+// TestBufferPoolPutReclassifiesByCapacity guards against Put trusting a
+// stale Buffer.class: a buffer fetched from a small class but grown past
+// its capacity (e.g. by bson.MarshalAppend) must land back in the pool
+// bucket matching its new capacity, not the one it was originally handed
+// out from.
+func TestBufferPoolPutReclassifiesByCapacity(t *testing.T) {
+	pool := NewBufferPool()
+
+	buf := pool.Get(0)
+	if buf.class != 0 {
+		t.Fatalf("Get(0) landed in class %d, want 0 (smallest)", buf.class)
+	}
+
+	// Simulate growth well past the 4KB class, into the 1MB class.
+	buf.B = make([]byte, 0, sizeClasses[4])
+	pool.Put(buf)
+
+	if buf.class != 4 {
+		t.Fatalf("Put did not reclassify grown buffer: got class %d, want 4", buf.class)
+	}
+
+	// The smallest class's pool must not have received the grown buffer.
+	if v := pool.pools[0].Get(); v != nil {
+		if got := v.(*Buffer); cap(got.B) > sizeClasses[0] {
+			t.Fatalf("class 0 pool handed out an oversized buffer with cap %d", cap(got.B))
+		}
+	}
+
+	got := pool.Get(sizeClasses[4])
+	if cap(got.B) < sizeClasses[4] {
+		t.Fatalf("Get(%d) returned cap %d", sizeClasses[4], cap(got.B))
+	}
+}
+
+// TestBufferPoolPutDropsOversizedBuffer checks that a buffer grown beyond
+// every size class is dropped rather than pooled under the wrong bucket.
+func TestBufferPoolPutDropsOversizedBuffer(t *testing.T) {
+	pool := NewBufferPool()
+
+	oversized := &Buffer{B: make([]byte, 0, sizeClasses[len(sizeClasses)-1]+1), class: 0}
+	pool.Put(oversized)
+
+	got := pool.Get(sizeClasses[0])
+	if cap(got.B) > sizeClasses[0] {
+		t.Fatalf("dropped oversized buffer was handed back out by the smallest class: cap %d", cap(got.B))
+	}
+}
@@ -0,0 +1,28 @@
+package compression
+
+import "github.com/klauspost/compress/s2"
+
+// S2Codec compresses payloads with S2, klauspost's throughput-tuned
+// extension of Snappy.
+type S2Codec struct{}
+
+// NewS2Codec returns an S2 Codec.
+func NewS2Codec() *S2Codec { return &S2Codec{} }
+
+func (c *S2Codec) Name() string { return "s2" }
+
+func (c *S2Codec) Compress(src []byte) ([]byte, error) {
+	return c.CompressInto(nil, src)
+}
+
+func (c *S2Codec) CompressInto(dst, src []byte) ([]byte, error) {
+	return s2.Encode(dst[:0], src), nil
+}
+
+func (c *S2Codec) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressInto(nil, src)
+}
+
+func (c *S2Codec) DecompressInto(dst, src []byte) ([]byte, error) {
+	return s2.Decode(dst[:0], src)
+}
@@ -0,0 +1,65 @@
+package compression
+
+import "github.com/klauspost/compress/zstd"
+
+// rawDictID is the ID ZstdCodec registers its raw-content dictionary under.
+// Only one dictionary is ever loaded per codec instance, so a fixed ID is
+// fine — it just needs to match between WithEncoderDictRaw and
+// WithDecoderDictRaw.
+const rawDictID = 1
+
+// ZstdCodec compresses payloads with zstd. Passing a trained dictionary
+// (see TrainDictionary) improves the ratio on documents too small to carry
+// enough internal repetition for dictionaryless mode to find. TrainDictionary
+// produces a raw-content dictionary (concatenated sample bytes), not the
+// `zstd --train` binary format, so it's loaded via the raw-content dict
+// options rather than WithEncoderDict/WithDecoderDicts.
+type ZstdCodec struct {
+	dict []byte
+}
+
+// NewZstdCodec returns a zstd Codec. dict may be nil to use the default,
+// dictionaryless encoder.
+func NewZstdCodec(dict []byte) *ZstdCodec {
+	return &ZstdCodec{dict: dict}
+}
+
+func (c *ZstdCodec) Name() string { return "zstd" }
+
+func (c *ZstdCodec) Compress(src []byte) ([]byte, error) {
+	return c.CompressInto(nil, src)
+}
+
+func (c *ZstdCodec) CompressInto(dst, src []byte) ([]byte, error) {
+	opts := []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedDefault)}
+	if c.dict != nil {
+		opts = append(opts, zstd.WithEncoderDictRaw(rawDictID, c.dict))
+	}
+
+	enc, err := zstd.NewWriter(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+
+	return enc.EncodeAll(src, dst[:0]), nil
+}
+
+func (c *ZstdCodec) Decompress(src []byte) ([]byte, error) {
+	return c.DecompressInto(nil, src)
+}
+
+func (c *ZstdCodec) DecompressInto(dst, src []byte) ([]byte, error) {
+	var opts []zstd.DOption
+	if c.dict != nil {
+		opts = append(opts, zstd.WithDecoderDictRaw(rawDictID, c.dict))
+	}
+
+	dec, err := zstd.NewReader(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	return dec.DecodeAll(src, dst[:0])
+}
@@ -0,0 +1,35 @@
+package compression
+
+import "testing"
+
+// TestZstdCodecDictionaryRoundTrip guards against regressing to the
+// `zstd --train`-formatted dictionary APIs (WithEncoderDict/WithDecoderDicts),
+// which reject the raw-content dictionaries TrainDictionary produces with a
+// "magic number mismatch" error.
+func TestZstdCodecDictionaryRoundTrip(t *testing.T) {
+	samples := [][]byte{
+		[]byte("the quick brown fox jumps over the lazy dog, repeatedly, for dictionary material"),
+		[]byte("another sample document sharing some structure with the first one"),
+	}
+	dict := TrainDictionary(samples, 0)
+	if len(dict) == 0 {
+		t.Fatal("TrainDictionary returned an empty dictionary")
+	}
+
+	codec := NewZstdCodec(dict)
+	src := []byte("the quick brown fox jumps over the lazy dog one more time")
+
+	compressed, err := codec.Compress(src)
+	if err != nil {
+		t.Fatalf("Compress with dictionary failed: %v", err)
+	}
+
+	decompressed, err := codec.Decompress(compressed)
+	if err != nil {
+		t.Fatalf("Decompress with dictionary failed: %v", err)
+	}
+
+	if string(decompressed) != string(src) {
+		t.Fatalf("round trip mismatch: got %q, want %q", decompressed, src)
+	}
+}
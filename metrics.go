@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsRegistry exposes a batch of CompressionResults as Prometheus
+// metrics on an embedded /metrics endpoint, so the demo can run as a
+// scheduled compression regression job scraped by a CI pipeline's
+// Prometheus, rather than only printing to a terminal.
+type MetricsRegistry struct {
+	registry         *prometheus.Registry
+	insertLatency    *prometheus.HistogramVec
+	queryLatency     *prometheus.HistogramVec
+	compressionRatio *prometheus.GaugeVec
+	bytesSavedTotal  *prometheus.CounterVec
+}
+
+// NewMetricsRegistry returns a MetricsRegistry with its collectors
+// registered under the "compression_demo" namespace.
+func NewMetricsRegistry() *MetricsRegistry {
+	m := &MetricsRegistry{
+		registry: prometheus.NewRegistry(),
+		insertLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "compression_demo",
+			Name:      "insert_latency_seconds",
+			Help:      "Insert latency for one codec run, by algorithm.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"algorithm"}),
+		queryLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "compression_demo",
+			Name:      "query_latency_seconds",
+			Help:      "Average read-workload query latency, by algorithm.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"algorithm"}),
+		compressionRatio: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "compression_demo",
+			Name:      "compression_ratio",
+			Help:      "Original size divided by compressed size, by algorithm.",
+		}, []string{"algorithm"}),
+		bytesSavedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "compression_demo",
+			Name:      "bytes_saved_total",
+			Help:      "Cumulative bytes saved by compression, by algorithm.",
+		}, []string{"algorithm"}),
+	}
+
+	m.registry.MustRegister(m.insertLatency, m.queryLatency, m.compressionRatio, m.bytesSavedTotal)
+	return m
+}
+
+// Record adds one batch of CompressionResults to the metrics. Skipped
+// results only contribute latency observations, since they carry no
+// meaningful compression ratio or bytes-saved figure.
+func (m *MetricsRegistry) Record(results []CompressionResult) {
+	for _, result := range results {
+		m.insertLatency.WithLabelValues(result.Algorithm).Observe(result.InsertTime.Seconds())
+		m.queryLatency.WithLabelValues(result.Algorithm).Observe(result.QueryTime.Seconds())
+
+		if result.Skipped {
+			continue
+		}
+		m.compressionRatio.WithLabelValues(result.Algorithm).Set(result.CompressionRatio)
+
+		// A counter must never receive a negative delta: small or
+		// near-incompressible payloads can come out larger than they went
+		// in (container overhead from gzip/flate/lz4 headers), so clamp
+		// rather than let Add panic.
+		saved := result.OriginalSize - result.CompressedSize
+		if saved < 0 {
+			saved = 0
+		}
+		m.bytesSavedTotal.WithLabelValues(result.Algorithm).Add(float64(saved))
+	}
+}
+
+// Serve blocks serving /metrics on addr until the HTTP server errors.
+func (m *MetricsRegistry) Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	return http.ListenAndServe(addr, mux)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dassandeep/mongo-compression-demo/compression"
+)
+
+// QueryKind identifies one read access pattern a WorkloadProfile can mix
+// into its operation stream.
+type QueryKind int
+
+const (
+	QueryPointLookup QueryKind = iota
+	QueryRangeScan
+	QueryProjection
+)
+
+func (k QueryKind) String() string {
+	switch k {
+	case QueryPointLookup:
+		return "point lookup"
+	case QueryRangeScan:
+		return "range scan"
+	case QueryProjection:
+		return "projection"
+	default:
+		return "unknown"
+	}
+}
+
+// WorkloadProfile describes a mixed read/write access pattern so codecs
+// can be compared under something closer to a real workload than a single
+// insert. ReadRatio of the profile's operations are reads cycling through
+// Queries; the rest are writes that insert another sibling document.
+type WorkloadProfile struct {
+	Name        string
+	ReadRatio   float64
+	Concurrency int
+	CorpusSize  int
+	Queries     []QueryKind
+}
+
+// ReadHeavyWorkload favors point lookups and range scans over writes,
+// representative of a cache-miss or analytics read path.
+var ReadHeavyWorkload = WorkloadProfile{
+	Name:        "read-heavy",
+	ReadRatio:   0.9,
+	Concurrency: 4,
+	CorpusSize:  20,
+	Queries:     []QueryKind{QueryPointLookup, QueryPointLookup, QueryRangeScan, QueryProjection},
+}
+
+// WriteHeavyWorkload favors inserts, representative of an ingest path.
+var WriteHeavyWorkload = WorkloadProfile{
+	Name:        "write-heavy",
+	ReadRatio:   0.1,
+	Concurrency: 4,
+	CorpusSize:  20,
+	Queries:     []QueryKind{QueryPointLookup},
+}
+
+// cpuTimeNow returns the process's total user+system CPU time so far.
+// Callers take the delta across a span of work to measure CPU time spent
+// in it, independent of wall-clock time lost to scheduling or I/O waits.
+func cpuTimeNow() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
+
+// runWorkload replays profile's read/write mix against collection using
+// corpus-sized set of sibling documents sharing payload, and reports the
+// average per-operation latency, total bytes read back over the wire, and
+// process CPU time spent across all of its reads and writes. skip must match whatever
+// testCompression's policy.Evaluate decided for payload: when true, payload
+// was stored uncompressed, so reads must not attempt to decompress it.
+func (d *CompressionDemo) runWorkload(codec compression.Codec, collection *mongo.Collection, payload []byte, rawSize int64, skip bool, profile WorkloadProfile) (queryTime time.Duration, bytesOverWire int64, cpuTimeDecode time.Duration, err error) {
+	totalOps := profile.CorpusSize
+	if totalOps <= 0 {
+		totalOps = 1
+	}
+	concurrency := profile.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	queries := profile.Queries
+	if len(queries) == 0 {
+		queries = []QueryKind{QueryPointLookup}
+	}
+
+	type opResult struct {
+		wallTime  time.Duration
+		wireBytes int64
+	}
+
+	opCh := make(chan int)
+	results := make(chan opResult, totalOps)
+	errs := make(chan error, totalOps)
+
+	cpuStart := cpuTimeNow()
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range opCh {
+				if isReadOp(i, profile.ReadRatio) {
+					wallTime, wireBytes, err := runQuery(d.ctx, collection, codec, skip, queries[i%len(queries)])
+					if err != nil {
+						errs <- err
+						continue
+					}
+					results <- opResult{wallTime: wallTime, wireBytes: wireBytes}
+					continue
+				}
+
+				sibling := compressedDocument{
+					ID:        primitive.NewObjectID(),
+					Algorithm: codec.Name(),
+					Payload:   primitive.Binary{Data: payload},
+					RawLength: rawSize,
+				}
+				start := time.Now()
+				if _, err := collection.InsertOne(d.ctx, sibling); err != nil {
+					errs <- err
+					continue
+				}
+				results <- opResult{wallTime: time.Since(start)}
+			}
+		}()
+	}
+
+	for i := 0; i < totalOps; i++ {
+		opCh <- i
+	}
+	close(opCh)
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	cpuTimeDecode = cpuTimeNow() - cpuStart
+
+	select {
+	case err = <-errs:
+		return 0, 0, 0, err
+	default:
+	}
+
+	var totalWall time.Duration
+	var opCount int
+	for res := range results {
+		totalWall += res.wallTime
+		bytesOverWire += res.wireBytes
+		opCount++
+	}
+	if opCount > 0 {
+		queryTime = totalWall / time.Duration(opCount)
+	}
+
+	return queryTime, bytesOverWire, cpuTimeDecode, nil
+}
+
+// isReadOp deterministically assigns op index i to the read or write share
+// of a workload, so a ReadRatio of 0.9 sends 9 of every 10 operations down
+// the read path.
+func isReadOp(i int, readRatio float64) bool {
+	return int(float64(i+1)*readRatio) > int(float64(i)*readRatio)
+}
+
+// runQuery executes one read of kind against collection, decompressing
+// whatever BinData payload(s) it returns with codec, and reports the wall
+// time and bytes read over the wire. skip must match testCompression's
+// policy decision for the stored payload: when true, the payload was
+// stored uncompressed (the policy judged it not worth compressing), so it
+// is not run through codec.Decompress.
+func runQuery(ctx context.Context, collection *mongo.Collection, codec compression.Codec, skip bool, kind QueryKind) (time.Duration, int64, error) {
+	start := time.Now()
+	var wireBytes int64
+
+	switch kind {
+	case QueryPointLookup:
+		var out compressedDocument
+		if err := collection.FindOne(ctx, bson.M{}).Decode(&out); err != nil {
+			return 0, 0, fmt.Errorf("point lookup: %w", err)
+		}
+		wireBytes = int64(len(out.Payload.Data))
+		if !skip {
+			if _, err := codec.Decompress(out.Payload.Data); err != nil {
+				return 0, 0, fmt.Errorf("point lookup decode: %w", err)
+			}
+		}
+
+	case QueryRangeScan:
+		cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetLimit(5))
+		if err != nil {
+			return 0, 0, fmt.Errorf("range scan: %w", err)
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var out compressedDocument
+			if err := cursor.Decode(&out); err != nil {
+				return 0, 0, fmt.Errorf("range scan decode: %w", err)
+			}
+			wireBytes += int64(len(out.Payload.Data))
+			if !skip {
+				if _, err := codec.Decompress(out.Payload.Data); err != nil {
+					return 0, 0, fmt.Errorf("range scan decompress: %w", err)
+				}
+			}
+		}
+		if err := cursor.Err(); err != nil {
+			return 0, 0, fmt.Errorf("range scan cursor: %w", err)
+		}
+
+	case QueryProjection:
+		var out struct {
+			Algorithm string `bson:"algorithm"`
+		}
+		projection := options.FindOne().SetProjection(bson.M{"algorithm": 1})
+		if err := collection.FindOne(ctx, bson.M{}, projection).Decode(&out); err != nil {
+			return 0, 0, fmt.Errorf("projection: %w", err)
+		}
+	}
+
+	return time.Since(start), wireBytes, nil
+}